@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// cloudEventTypePrefix maps CloudEvents whose "type" is "<prefix>.updated" onto the existing
+// Akeyless secret-update patch flow. Defaults to the Akeyless secret event, but can be
+// repointed at any other producer's event type.
+var cloudEventTypePrefix = getEnvOrDefault("CLOUDEVENTS_TYPE_PREFIX", "io.akeyless.secret")
+
+// cloudEvent holds the CloudEvents v1.0 context attributes and data we care about.
+type cloudEvent struct {
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	Data            json.RawMessage `json:"data,omitempty"`
+}
+
+// isCloudEventRequest reports whether r carries a CloudEvents v1.0 payload: either a
+// structured "application/cloudevents+json" body, or the binary HTTP content mode, which
+// carries the envelope attributes in Ce-* headers and the event data directly in the body.
+func isCloudEventRequest(r *http.Request) bool {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/cloudevents+json") {
+		return true
+	}
+	return r.Header.Get("Ce-Id") != "" && r.Header.Get("Ce-Type") != ""
+}
+
+// decodeCloudEvent parses a CloudEvents request in either structured or binary mode.
+func decodeCloudEvent(r *http.Request, body []byte) (*cloudEvent, error) {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/cloudevents+json") {
+		var ce cloudEvent
+		if err := json.Unmarshal(body, &ce); err != nil {
+			return nil, fmt.Errorf("failed to decode structured CloudEvent: %w", err)
+		}
+		return &ce, nil
+	}
+
+	return &cloudEvent{
+		ID:              r.Header.Get("Ce-Id"),
+		Source:          r.Header.Get("Ce-Source"),
+		Type:            r.Header.Get("Ce-Type"),
+		Subject:         r.Header.Get("Ce-Subject"),
+		DataContentType: r.Header.Get("Ce-Datacontenttype"),
+		Data:            body,
+	}, nil
+}
+
+// isSecretUpdatedEventType reports whether a CloudEvent type maps to the Akeyless
+// secret-update patch flow, e.g. "io.akeyless.secret.updated".
+func isSecretUpdatedEventType(eventType string) bool {
+	return eventType == cloudEventTypePrefix+".updated"
+}
+
+// cloudEventToEvents converts a CloudEvent into the []Event shape WebhookHandler already
+// knows how to process, using the subject as the item name and falling back to the
+// "item_name" field of the event data. CloudEvents whose type we don't recognize are
+// translated to an empty event list rather than an error, so they're acknowledged and ignored.
+func cloudEventToEvents(ce *cloudEvent) ([]Event, error) {
+	if !isSecretUpdatedEventType(ce.Type) {
+		return nil, nil
+	}
+
+	itemName := ce.Subject
+	if itemName == "" {
+		var data struct {
+			ItemName string `json:"item_name"`
+		}
+		if len(ce.Data) > 0 {
+			if err := json.Unmarshal(ce.Data, &data); err != nil {
+				return nil, fmt.Errorf("failed to decode CloudEvent data: %w", err)
+			}
+		}
+		itemName = data.ItemName
+	}
+
+	if itemName == "" {
+		return nil, fmt.Errorf("CloudEvent %s has neither subject nor data.item_name", ce.ID)
+	}
+
+	return []Event{{ItemName: itemName}}, nil
+}
+
+func getEnvOrDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,376 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gofr.dev/pkg/gofr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// externalSecretRef identifies a single ExternalSecret that references a given remote key.
+type externalSecretRef struct {
+	Namespace string
+	Name      string
+}
+
+// externalSecretIndexerConfig scopes which ExternalSecrets the indexer watches, so a trigger
+// deployment in a multi-tenant cluster only needs RBAC for the namespaces/labels it actually
+// cares about instead of a cluster-wide list.
+type externalSecretIndexerConfig struct {
+	// WatchNamespaces is the namespace allow-list. Empty means watch every namespace.
+	WatchNamespaces []string
+	// ExcludeNamespaces is skipped when WatchNamespaces is empty (cluster-wide watch).
+	ExcludeNamespaces map[string]struct{}
+	// LabelSelector is applied to every List/Watch call the indexer makes.
+	LabelSelector string
+	// ResyncOnlyMatching refuses to start an indexer that would fall back to an unscoped
+	// cluster-wide watch, so a misconfiguration can't silently widen the blast radius.
+	ResyncOnlyMatching bool
+}
+
+// externalSecretIndexer watches ExternalSecret resources, scoped by externalSecretIndexerConfig,
+// via one shared informer per watched namespace, and maintains an in-memory reverse index of
+// remoteRef key -> the ExternalSecrets that reference it. patchExternalSecret consults this
+// index instead of scanning every namespace on every webhook event.
+type externalSecretIndexer struct {
+	mu    sync.RWMutex
+	index map[string][]externalSecretRef
+	// keysByObject is the forward side of index: "namespace/name" -> the remote keys it last
+	// contributed. rebuild uses it to drop exactly the stale entries for an object instead of
+	// scanning every key in index, which matters once a cluster has thousands of ExternalSecrets.
+	keysByObject map[string][]string
+
+	excludeNamespaces map[string]struct{}
+	informers         []cache.SharedIndexInformer
+	queue             workqueue.RateLimitingInterface
+
+	// watchHealthy tracks whether every informer's underlying watch is currently healthy (1) or
+	// has hit a watch error since its last successful event (0). HasSynced never goes back to
+	// false once a watch-based informer's initial list completes, even if the watch later drops
+	// and has to relist, so Synced also consults this to catch a post-startup loss of sync.
+	watchHealthy int32
+}
+
+// externalSecretIndexerConfigFromEnv reads WATCH_NAMESPACES, EXCLUDE_NAMESPACES,
+// EXTERNALSECRET_LABEL_SELECTOR and RESYNC_ONLY_MATCHING into an externalSecretIndexerConfig.
+func externalSecretIndexerConfigFromEnv() externalSecretIndexerConfig {
+	return externalSecretIndexerConfig{
+		WatchNamespaces:    splitCSVEnv("WATCH_NAMESPACES"),
+		ExcludeNamespaces:  toSet(splitCSVEnv("EXCLUDE_NAMESPACES")),
+		LabelSelector:      os.Getenv("EXTERNALSECRET_LABEL_SELECTOR"),
+		ResyncOnlyMatching: os.Getenv("RESYNC_ONLY_MATCHING") == "true",
+	}
+}
+
+// splitCSVEnv splits a comma-separated env var into trimmed, non-empty entries.
+func splitCSVEnv(key string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return nil
+	}
+
+	var out []string
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			out = append(out, entry)
+		}
+	}
+	return out
+}
+
+func toSet(values []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		set[v] = struct{}{}
+	}
+	return set
+}
+
+// newExternalSecretIndexer builds an indexer backed by dynamic shared informers for
+// ExternalSecretGVR, scoped per cfg. Call Start to begin watching and populating the index.
+func newExternalSecretIndexer(dynamicClient dynamic.Interface, resync time.Duration, cfg externalSecretIndexerConfig) (*externalSecretIndexer, error) {
+	if cfg.ResyncOnlyMatching && len(cfg.WatchNamespaces) == 0 && cfg.LabelSelector == "" {
+		return nil, fmt.Errorf("RESYNC_ONLY_MATCHING=true requires WATCH_NAMESPACES and/or EXTERNALSECRET_LABEL_SELECTOR, otherwise every resync would be an unscoped cluster-wide watch")
+	}
+
+	idx := &externalSecretIndexer{
+		index:             make(map[string][]externalSecretRef),
+		keysByObject:      make(map[string][]string),
+		excludeNamespaces: cfg.ExcludeNamespaces,
+		queue:             workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		watchHealthy:      1,
+	}
+
+	tweakListOptions := func(opts *metav1.ListOptions) {
+		opts.LabelSelector = cfg.LabelSelector
+	}
+
+	namespaces := cfg.WatchNamespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+
+	for _, namespace := range namespaces {
+		listWatch := idx.newListWatch(dynamicClient, namespace, tweakListOptions)
+		informer := cache.NewSharedIndexInformer(listWatch, &unstructured.Unstructured{}, resync, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+		informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(obj interface{}) { idx.enqueue(obj) },
+			UpdateFunc: func(_, newObj interface{}) { idx.enqueue(newObj) },
+			DeleteFunc: func(obj interface{}) { idx.enqueue(obj) },
+		})
+		if err := informer.SetWatchErrorHandler(func(_ *cache.Reflector, err error) {
+			atomic.StoreInt32(&idx.watchHealthy, 0)
+		}); err != nil {
+			return nil, fmt.Errorf("failed to set watch error handler: %w", err)
+		}
+		idx.informers = append(idx.informers, informer)
+	}
+
+	return idx, nil
+}
+
+// newListWatch builds a ListWatch for ExternalSecretGVR in namespace that marks watchHealthy on
+// every List or Watch call that actually succeeds. That's the real signal that the connection to
+// the apiserver has recovered -- unlike enqueue, it fires even when the watched
+// namespace/selector currently matches no objects, so a recovered watch over an empty result set
+// doesn't leave /readyz wedged reporting "not ready" forever.
+func (idx *externalSecretIndexer) newListWatch(dynamicClient dynamic.Interface, namespace string, tweakListOptions func(*metav1.ListOptions)) *cache.ListWatch {
+	resource := dynamicClient.Resource(ExternalSecretGVR).Namespace(namespace)
+	return &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			tweakListOptions(&options)
+			list, err := resource.List(context.TODO(), options)
+			if err == nil {
+				atomic.StoreInt32(&idx.watchHealthy, 1)
+			}
+			return list, err
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			tweakListOptions(&options)
+			w, err := resource.Watch(context.TODO(), options)
+			if err == nil {
+				atomic.StoreInt32(&idx.watchHealthy, 1)
+			}
+			return w, err
+		},
+	}
+}
+
+// Start runs every configured informer and, once their initial lists have synced, starts the
+// worker that rebuilds the index from queued add/update/delete notifications.
+func (idx *externalSecretIndexer) Start(stopCh <-chan struct{}) error {
+	for _, informer := range idx.informers {
+		go informer.Run(stopCh)
+	}
+
+	for _, informer := range idx.informers {
+		if !cache.WaitForCacheSync(stopCh, informer.HasSynced) {
+			return fmt.Errorf("timed out waiting for ExternalSecret informer cache to sync")
+		}
+	}
+
+	go idx.runWorker(stopCh)
+	return nil
+}
+
+// enqueue schedules an index rebuild for the ExternalSecret behind obj, unless its namespace is
+// on the exclude list (which only applies to the unscoped, cluster-wide watch).
+func (idx *externalSecretIndexer) enqueue(obj interface{}) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			return
+		}
+		u, ok = tombstone.Obj.(*unstructured.Unstructured)
+		if !ok {
+			return
+		}
+	}
+	if _, excluded := idx.excludeNamespaces[u.GetNamespace()]; excluded {
+		return
+	}
+	idx.queue.Add(u.GetNamespace() + "/" + u.GetName())
+}
+
+func (idx *externalSecretIndexer) runWorker(stopCh <-chan struct{}) {
+	for idx.processNextItem() {
+	}
+}
+
+func (idx *externalSecretIndexer) processNextItem() bool {
+	key, shutdown := idx.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer idx.queue.Done(key)
+
+	idx.rebuild(key.(string))
+	idx.queue.Forget(key)
+	return true
+}
+
+// rebuild drops any index entries previously contributed by the "namespace/name" object and,
+// if it still exists in the informer's store, re-adds entries for its current remoteRef keys.
+// It only ever touches the keys that object contributed (tracked in keysByObject), not every key
+// in the index, so cost is proportional to one object's remoteRef count, not the whole index.
+func (idx *externalSecretIndexer) rebuild(namespacedName string) {
+	namespace, name, ok := strings.Cut(namespacedName, "/")
+	if !ok {
+		return
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	for _, key := range idx.keysByObject[namespacedName] {
+		refs := idx.index[key][:0]
+		for _, ref := range idx.index[key] {
+			if ref.Namespace != namespace || ref.Name != name {
+				refs = append(refs, ref)
+			}
+		}
+		if len(refs) == 0 {
+			delete(idx.index, key)
+		} else {
+			idx.index[key] = refs
+		}
+	}
+	delete(idx.keysByObject, namespacedName)
+
+	u, exists := idx.lookupStore(namespacedName)
+	if !exists {
+		return
+	}
+
+	keys := remoteRefKeys(u)
+	ref := externalSecretRef{Namespace: namespace, Name: name}
+	for _, key := range keys {
+		idx.index[key] = append(idx.index[key], ref)
+	}
+	if len(keys) > 0 {
+		idx.keysByObject[namespacedName] = keys
+	}
+}
+
+// lookupStore finds "namespace/name" in whichever per-namespace informer's local store holds
+// it. Namespaces watched under WATCH_NAMESPACES each have their own informer, so there's no
+// single store to check.
+func (idx *externalSecretIndexer) lookupStore(namespacedName string) (*unstructured.Unstructured, bool) {
+	for _, informer := range idx.informers {
+		obj, exists, err := informer.GetIndexer().GetByKey(namespacedName)
+		if err != nil || !exists {
+			continue
+		}
+		if u, ok := obj.(*unstructured.Unstructured); ok {
+			return u, true
+		}
+	}
+	return nil, false
+}
+
+// remoteRefKeys extracts every normalized remote key referenced by an ExternalSecret's
+// spec.data[*].remoteRef.key and spec.dataFrom[*].extract.key entries.
+//
+// spec.dataFrom[*].find entries are deliberately not indexed: find.name is a regexp/tag
+// matcher against potentially many provider keys, not a single literal key, so it doesn't fit
+// this reverse index (remote key -> ExternalSecrets). ExternalSecrets that only use
+// dataFrom.find won't be patched promptly by the webhook trigger; they still pick up changes on
+// the provider SDK's normal resync interval.
+func remoteRefKeys(es *unstructured.Unstructured) []string {
+	var keys []string
+
+	spec, found, err := unstructured.NestedMap(es.Object, "spec")
+	if err != nil || !found {
+		return keys
+	}
+
+	if dataList, found, err := unstructured.NestedSlice(spec, "data"); err == nil && found {
+		for _, item := range dataList {
+			dataMap, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			remoteRef, found, err := unstructured.NestedMap(dataMap, "remoteRef")
+			if err != nil || !found {
+				continue
+			}
+			if key, found, err := unstructured.NestedString(remoteRef, "key"); err == nil && found {
+				keys = append(keys, normalizeRemoteRefKey(key))
+			}
+		}
+	}
+
+	if dataFromList, found, err := unstructured.NestedSlice(spec, "dataFrom"); err == nil && found {
+		for _, item := range dataFromList {
+			dataFromMap, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if extract, found, err := unstructured.NestedMap(dataFromMap, "extract"); err == nil && found {
+				if key, found, err := unstructured.NestedString(extract, "key"); err == nil && found {
+					keys = append(keys, normalizeRemoteRefKey(key))
+				}
+			}
+		}
+	}
+
+	return keys
+}
+
+func normalizeRemoteRefKey(key string) string {
+	return strings.TrimPrefix(key, "/")
+}
+
+// Lookup returns the ExternalSecrets currently indexed against the given remote key.
+func (idx *externalSecretIndexer) Lookup(key string) []externalSecretRef {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	refs := idx.index[normalizeRemoteRefKey(key)]
+	out := make([]externalSecretRef, len(refs))
+	copy(out, refs)
+	return out
+}
+
+// Synced reports whether every configured informer's initial list has completed and none of
+// their watches has since hit an error it hasn't recovered from. HasSynced alone only answers
+// the startup question and never flips back once true, so it can't catch a watch that later
+// drops and has to relist; watchHealthy is what lets ReadyzHandler observe that.
+func (idx *externalSecretIndexer) Synced() bool {
+	if len(idx.informers) == 0 {
+		return false
+	}
+	for _, informer := range idx.informers {
+		if !informer.HasSynced() {
+			return false
+		}
+	}
+	return atomic.LoadInt32(&idx.watchHealthy) == 1
+}
+
+// HealthzHandler reports liveness: the process is up and serving.
+func (idx *externalSecretIndexer) HealthzHandler(ctx *gofr.Context) (interface{}, error) {
+	return map[string]string{"status": "ok"}, nil
+}
+
+// ReadyzHandler reports readiness: the ExternalSecret informer cache must have completed its
+// initial sync before the trigger can be trusted to find matching ExternalSecrets.
+func (idx *externalSecretIndexer) ReadyzHandler(ctx *gofr.Context) (interface{}, error) {
+	if !idx.Synced() {
+		return nil, fmt.Errorf("ExternalSecret informer cache not yet synced")
+	}
+	return map[string]string{"status": "ready"}, nil
+}
@@ -1,17 +1,24 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"log"
+	"math/rand"
 	"net/http"
 	"os"
+	"strconv"
 	"time"
-	"strings"
 
 	"gofr.dev/pkg/gofr"
 	gofrHTTP "gofr.dev/pkg/gofr/http"
+	"gofr.dev/pkg/gofr/logging"
 
 	// Kubernetes client imports
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured" // Add this import
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -29,10 +36,21 @@ var ExternalSecretGVR = schema.GroupVersionResource{
 
 // BasicAuth credentials as GoFr environment variables
 var (
-	basicAuthUser           = os.Getenv("BASIC_AUTH_USER")
-	basicAuthPassword       = os.Getenv("BASIC_AUTH_PASSWORD")
-	enableCacheBuster       = os.Getenv("ENABLE_CACHE_BUSTER") == "true"
-	cacheBusterWaitInterval = 2 * time.Second
+	basicAuthUser     = os.Getenv("BASIC_AUTH_USER")
+	basicAuthPassword = os.Getenv("BASIC_AUTH_PASSWORD")
+)
+
+// Bounds for the optimistic-concurrency retry loop in updateExternalSecret.
+const (
+	maxUpdateRetries     = 5
+	updateRetryBaseDelay = 100 * time.Millisecond
+)
+
+// dynamicClient and esIndexer are wired up once in main() and shared by every request,
+// instead of being rebuilt per webhook call.
+var (
+	dynamicClient dynamic.Interface
+	esIndexer     *externalSecretIndexer
 )
 
 var dynamicNewForConfig = dynamic.NewForConfig
@@ -43,6 +61,15 @@ func createDynamicClient(config *rest.Config) (dynamic.Interface, error) {
 	return dynamicNewForConfig(config)
 }
 
+// eventLogger is the logging surface patchExternalSecret and updateExternalSecret need. The
+// HTTP webhook path satisfies it with gofr's ctx.Logger; other event sources (e.g. MQTT) can
+// pass any other logging.Logger, which keeps the reconciler core independent of the transport.
+type eventLogger interface {
+	Infof(format string, args ...any)
+	Warnf(format string, args ...any)
+	Errorf(format string, args ...any)
+}
+
 // Event represents the incoming webhook event
 type Event struct {
 	EventID    int               `json:"event_id,omitempty"`
@@ -55,12 +82,19 @@ type Event struct {
 }
 
 // We are making sure that the content type is set to "application/json; charset=utf-8"
-// so that the WebhookHandler can parse the incoming events
+// so that the WebhookHandler can parse the incoming events. Requests carrying a CloudEvents
+// v1.0 envelope (structured "application/cloudevents+json", or the binary Ce-* header mode)
+// are first translated into that same []Event shape, so WebhookHandler doesn't need to know
+// anything about CloudEvents.
 func customMiddleware() gofrHTTP.Middleware {
 	return func(inner http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Check if the Content-Type header is not set to application/json
-			if r.Header.Get("Content-Type") != "application/json; charset=utf-8" {
+			if isCloudEventRequest(r) {
+				if err := translateCloudEventRequest(r); err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+			} else if r.Header.Get("Content-Type") != "application/json; charset=utf-8" {
 				// Set the Content-Type header to application/json
 				r.Header.Set("Content-Type", "application/json; charset=utf-8")
 			}
@@ -71,6 +105,36 @@ func customMiddleware() gofrHTTP.Middleware {
 	}
 }
 
+// translateCloudEventRequest rewrites r in place, replacing its CloudEvent body/headers with
+// the equivalent []Event JSON body that WebhookHandler expects.
+func translateCloudEventRequest(r *http.Request) error {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read CloudEvent request body: %w", err)
+	}
+	r.Body.Close()
+
+	ce, err := decodeCloudEvent(r, body)
+	if err != nil {
+		return err
+	}
+
+	events, err := cloudEventToEvents(ce)
+	if err != nil {
+		return err
+	}
+
+	encoded, err := json.Marshal(events)
+	if err != nil {
+		return fmt.Errorf("failed to encode translated CloudEvent: %w", err)
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(encoded))
+	r.ContentLength = int64(len(encoded))
+	r.Header.Set("Content-Type", "application/json; charset=utf-8")
+	return nil
+}
+
 func checkEnvironmentVariables() error {
 	if basicAuthUser == "" || basicAuthPassword == "" {
 		return fmt.Errorf("error: BASIC_AUTH_USER and BASIC_AUTH_PASSWORD environment variables must be set")
@@ -78,11 +142,91 @@ func checkEnvironmentVariables() error {
 	return nil
 }
 
+// buildKubeConfig builds the Kubernetes client configuration. It prefers an explicit
+// external-cluster config built from K8S_* environment variables (so a single deployment can
+// fan out to a remote cluster), then falls back to in-cluster config, then the local kubeconfig
+// for development.
+func buildKubeConfig() (*rest.Config, error) {
+	externalConfig, err := buildExternalKubeConfig()
+	if err != nil {
+		return nil, err
+	}
+	if externalConfig != nil {
+		return externalConfig, nil
+	}
+
+	config, err := rest.InClusterConfig()
+	if err == nil {
+		return config, nil
+	}
+
+	kubeconfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		clientcmd.NewDefaultClientConfigLoadingRules(),
+		&clientcmd.ConfigOverrides{},
+	)
+	return kubeconfig.ClientConfig()
+}
+
+// buildExternalKubeConfig builds a *rest.Config targeting a remote cluster from K8S_ENDPOINT,
+// K8S_BEARER_TOKEN (or K8S_BEARER_TOKEN_FILE for a token that rotates on disk), K8S_CA_FILE and
+// K8S_INSECURE_SKIP_VERIFY, mirroring how providers like Traefik's Kubernetes CRD provider let
+// a single instance target a cluster other than the one it runs in. Returns (nil, nil) when
+// K8S_ENDPOINT isn't set, so callers can fall through to in-cluster/kubeconfig discovery.
+func buildExternalKubeConfig() (*rest.Config, error) {
+	endpoint := os.Getenv("K8S_ENDPOINT")
+	if endpoint == "" {
+		return nil, nil
+	}
+
+	config := &rest.Config{Host: endpoint}
+
+	if caFile := os.Getenv("K8S_CA_FILE"); caFile != "" {
+		config.TLSClientConfig.CAFile = caFile
+	}
+	if os.Getenv("K8S_INSECURE_SKIP_VERIFY") == "true" {
+		config.TLSClientConfig.Insecure = true
+	}
+
+	switch tokenFile, token := os.Getenv("K8S_BEARER_TOKEN_FILE"), os.Getenv("K8S_BEARER_TOKEN"); {
+	case tokenFile != "":
+		// BearerTokenFile makes client-go reload the token from disk as it rotates, the same
+		// mechanism in-cluster configs rely on for projected service account tokens.
+		config.BearerTokenFile = tokenFile
+	case token != "":
+		config.BearerToken = token
+	default:
+		return nil, fmt.Errorf("K8S_BEARER_TOKEN or K8S_BEARER_TOKEN_FILE must be set when K8S_ENDPOINT is set")
+	}
+
+	return config, nil
+}
+
 func main() {
 	if err := checkEnvironmentVariables(); err != nil {
 		log.Fatal(err)
 	}
 
+	config, err := buildKubeConfig()
+	if err != nil {
+		log.Fatalf("Failed to load Kubernetes config: %v", err)
+	}
+
+	dynamicClient, err = dynamicClientCreator(config)
+	if err != nil {
+		log.Fatalf("Failed to create dynamic Kubernetes client: %v", err)
+	}
+
+	// Build and start the ExternalSecret reverse index before serving traffic, so that
+	// patchExternalSecret never has to fall back to a cluster-wide scan.
+	esIndexer, err = newExternalSecretIndexer(dynamicClient, 10*time.Minute, externalSecretIndexerConfigFromEnv())
+	if err != nil {
+		log.Fatalf("Invalid ExternalSecret indexer configuration: %v", err)
+	}
+	stopCh := make(chan struct{})
+	if err := esIndexer.Start(stopCh); err != nil {
+		log.Fatalf("Failed to start ExternalSecret informer: %v", err)
+	}
+
 	// Create a new GoFr app
 	app := gofr.New()
 
@@ -92,19 +236,48 @@ func main() {
 	// Register middleware for basic authentication
 	app.EnableBasicAuth(basicAuthUser, basicAuthPassword)
 
-	// Define the route for webhook events
-	app.POST("/webhook", WebhookHandler)
+	// Expose informer sync status for liveness/readiness probes regardless of source mode
+	app.GET("/healthz", esIndexer.HealthzHandler)
+	app.GET("/readyz", esIndexer.ReadyzHandler)
+
+	switch sourceMode := getEnvOrDefault("SOURCE_MODE", sourceModeHTTP); sourceMode {
+	case sourceModeHTTP:
+		// Define the route for webhook events
+		app.POST("/webhook", WebhookHandler)
+	case sourceModeMQTT:
+		// Subscribe to the configured MQTT topic instead of serving /webhook; events flow
+		// through the same patchExternalSecret pipeline either way.
+		go startMQTTEventSource()
+	default:
+		log.Fatalf("Unknown SOURCE_MODE %q (expected %q or %q)", sourceMode, sourceModeHTTP, sourceModeMQTT)
+	}
 
 	// Start the GoFr app
 	app.Run()
 }
 
+// startMQTTEventSource connects to the configured MQTT broker and feeds every message it
+// receives through patchExternalSecret, the same pipeline WebhookHandler drives over HTTP.
+func startMQTTEventSource() {
+	source, err := newMQTTEventSourceFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to configure MQTT event source: %v", err)
+	}
+
+	logger := logging.NewLogger(logging.INFO)
+	err = source.Start(func(itemName string) {
+		if err := patchExternalSecret(context.Background(), logger, itemName); err != nil {
+			logger.Errorf("Error patching ExternalSecret: %v", err)
+		}
+	})
+	if err != nil {
+		log.Fatalf("Failed to start MQTT event source: %v", err)
+	}
+}
+
 // WebhookHandler is the main handler for incoming webhook requests
 // It processes the incoming events and triggers the patching of ExternalSecrets if necessary.
 func WebhookHandler(ctx *gofr.Context) (interface{}, error) {
-	// Get the cache buster wait interval from the environment variable
-	cacheBusterWaitInterval = getEnvDuration(ctx, "CACHE_BUSTER_WAIT_INTERVAL", 2*time.Second)
-
 	// Decode the incoming webhook event into a slice of Event structs
 	var events []Event
 	if err := ctx.Bind(&events); err != nil {
@@ -121,7 +294,7 @@ func WebhookHandler(ctx *gofr.Context) (interface{}, error) {
 		ctx.Logger.Infof("Received event for secret update: %s\n", event.ItemName)
 
 		// Attempt to patch the ExternalSecret in Kubernetes based on the event
-		if err := patchExternalSecret(ctx, event.ItemName); err != nil {
+		if err := patchExternalSecret(ctx, ctx.Logger, event.ItemName); err != nil {
 			ctx.Logger.Errorf("Error patching ExternalSecret: %v", err)
 		}
 	}
@@ -130,248 +303,124 @@ func WebhookHandler(ctx *gofr.Context) (interface{}, error) {
 	return nil, nil
 }
 
-// patchExternalSecret looks for ExternalSecrets that match the incoming event and patches them
-// It logs the process and any errors encountered during the operation.
-func patchExternalSecret(ctx *gofr.Context, itemName string) error {
-	// Create Kubernetes client configuration
-	config, err := rest.InClusterConfig()
-	if err != nil {
-		// Fallback to kubeconfig for local development
-		kubeconfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
-			clientcmd.NewDefaultClientConfigLoadingRules(),
-			&clientcmd.ConfigOverrides{},
-		)
-		config, err = kubeconfig.ClientConfig()
-		if err != nil {
-			ctx.Logger.Fatalf("Failed to load kubeconfig: %v", err)
-			return err
-		}
+// patchExternalSecret looks up the ExternalSecrets that reference itemName in the in-memory
+// reverse index maintained by esIndexer, and patches each of them. This is an O(1) index
+// lookup instead of a List(namespaces) x List(externalsecrets) scan of the cluster.
+func patchExternalSecret(ctx context.Context, logger eventLogger, itemName string) error {
+	if esIndexer == nil {
+		return fmt.Errorf("ExternalSecret indexer is not initialized")
 	}
 
-	// Create a dynamic Kubernetes client
-	dynamicClient, err := dynamicClientCreator(config)
-	if err != nil {
-		ctx.Logger.Fatalf("Failed to create dynamic Kubernetes client: %v", err)
-	}
-
-	var namespaces []string
-
-	// Attempt to list all namespaces
-	namespacesList, err := dynamicClient.Resource(schema.GroupVersionResource{
-		Group:    "",
-		Version:  "v1",
-		Resource: "namespaces",
-	}).List(ctx, metav1.ListOptions{})
-	if err != nil {
-		ctx.Logger.Warnf("Failed to list namespaces: %v. Falling back to the deployed namespace.", err)
-		// Fallback to the current namespace
-		namespace, err := getNamespace()
-		if err != nil {
-			ctx.Logger.Errorf("Failed to get namespace: %v", err)
-			return err
-		}
-		namespaces = []string{namespace} // Only use the current namespace
-	} else {
-		// If listing succeeded, collect all namespace names
-		for _, ns := range namespacesList.Items {
-			namespaces = append(namespaces, ns.GetName())
-		}
+	refs := esIndexer.Lookup(itemName)
+	if len(refs) == 0 {
+		logger.Infof("No ExternalSecret references remote key '%s'\n", itemName)
+		return nil
 	}
 
-	// Iterate through each namespace
-	for _, namespace := range namespaces {
-		ctx.Logger.Infof("Checking namespace: %s", namespace)
-
-		// List all ExternalSecrets in the current namespace
-		externalSecrets, err := dynamicClient.Resource(ExternalSecretGVR).Namespace(namespace).List(ctx, metav1.ListOptions{})
-		if err != nil {
-			// Log error but continue with other namespaces if we don't have access
-			ctx.Logger.Errorf("Failed to list ExternalSecrets in namespace %s: %v", namespace, err)
-			continue
-		}
+	for _, ref := range refs {
+		logger.Infof("Desired key found in ExternalSecret %s/%s\n", ref.Namespace, ref.Name)
 
-		// Iterate over each ExternalSecret and process it
-		for _, es := range externalSecrets.Items {
-			name := es.GetName()
-			ctx.Logger.Infof("Processing ExternalSecret: %s in namespace %s\n", name, namespace)
-
-			// Access the spec field
-			spec, found, err := unstructured.NestedMap(es.Object, "spec")
-			if err != nil || !found {
-				ctx.Logger.Errorf("Error retrieving spec for ExternalSecret %s/%s: %v\n", namespace, name, err)
-				continue
+		var es *unstructured.Unstructured
+		err := retryableAPICall(ctx, logger, "Get ExternalSecret "+ref.Namespace+"/"+ref.Name, func() error {
+			fetched, err := dynamicClient.Resource(ExternalSecretGVR).Namespace(ref.Namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+			if err != nil {
+				return err
 			}
-
-			keyFound := false
-
-			// Check data[] structure
-			dataList, found, err := unstructured.NestedSlice(spec, "data")
-			if err == nil && found {
-				keyFound = checkDataStructure(ctx, dataList, itemName, name)
-			}
-
-			// Check dataFrom[] structure if key not found in data[]
-			if !keyFound {
-				dataFromList, found, err := unstructured.NestedSlice(spec, "dataFrom")
-				if err == nil && found {
-					keyFound = checkDataFromStructure(ctx, dataFromList, itemName, name)
-				}
-			}
-
-			if keyFound {
-				ctx.Logger.Infof("Desired key found in ExternalSecret %s/%s\n", namespace, name)
-				if err := updateExternalSecret(ctx, dynamicClient, &es, namespace); err != nil {
-					ctx.Logger.Errorf("Failed to update ExternalSecret %s/%s: %v\n", namespace, name, err)
-					return err
-				}
-				ctx.Logger.Infof("Successfully updated ExternalSecret %s/%s\n", namespace, name)
-			} else {
-				ctx.Logger.Infof("Desired key '%s' not found in ExternalSecret %s/%s\n", itemName, namespace, name)
-			}
-		}
-	}
-	return nil
-}
-
-func checkDataStructure(ctx *gofr.Context, dataList []interface{}, itemName, esName string) bool {
-	for _, item := range dataList {
-		dataMap, ok := item.(map[string]interface{})
-		if !ok {
-			ctx.Logger.Errorf("Invalid data item in ExternalSecret %s\n", esName)
-			continue
-		}
-
-		remoteRef, found, err := unstructured.NestedMap(dataMap, "remoteRef")
-		if err != nil || !found {
-			ctx.Logger.Errorf("remoteRef not found in data item of ExternalSecret %s: %v\n", esName, err)
+			es = fetched
+			return nil
+		})
+		if err != nil {
+			logger.Errorf("Failed to fetch ExternalSecret %s/%s: %v\n", ref.Namespace, ref.Name, err)
 			continue
 		}
 
-		key, found, err := unstructured.NestedString(remoteRef, "key")
-		if err != nil || !found {
-			ctx.Logger.Errorf("key not found in remoteRef of ExternalSecret %s: %v\n", esName, err)
+		if err := updateExternalSecret(ctx, logger, dynamicClient, es, ref.Namespace); err != nil {
+			logger.Errorf("Failed to update ExternalSecret %s/%s: %v\n", ref.Namespace, ref.Name, err)
 			continue
 		}
-
-		ctx.Logger.Infof("Found key in ExternalSecret %s data[]: %s\n", esName, key)
-
-		// Trim leading slashes for comparison
-		trimmedKey := strings.TrimPrefix(key, "/")
-		trimmedItemName := strings.TrimPrefix(itemName, "/")
-
-		if trimmedKey == trimmedItemName {
-			return true
-		}
+		logger.Infof("Successfully updated ExternalSecret %s/%s\n", ref.Namespace, ref.Name)
 	}
-	return false
-}
 
-func checkDataFromStructure(ctx *gofr.Context, dataFromList []interface{}, itemName, esName string) bool {
-	for _, item := range dataFromList {
-		dataFromMap, ok := item.(map[string]interface{})
-		if !ok {
-			ctx.Logger.Errorf("Invalid dataFrom item in ExternalSecret %s\n", esName)
-			continue
-		}
-
-		extract, found, err := unstructured.NestedMap(dataFromMap, "extract")
-		if err != nil || !found {
-			ctx.Logger.Errorf("extract not found in dataFrom item of ExternalSecret %s: %v\n", esName, err)
-			continue
-		}
-
-		key, found, err := unstructured.NestedString(extract, "key")
-		if err != nil || !found {
-			ctx.Logger.Errorf("key not found in extract of ExternalSecret %s: %v\n", esName, err)
-			continue
-		}
-
-		ctx.Logger.Infof("Found key in ExternalSecret %s dataFrom[]: %s\n", esName, key)
-
-		if key == itemName {
-			return true
-		}
-	}
-	return false
+	return nil
 }
 
-func updateExternalSecret(ctx *gofr.Context, dynamicClient dynamic.Interface, es *unstructured.Unstructured, namespace string) error {
+// updateExternalSecret mutates the ExternalSecret's annotations and applies the update,
+// retrying on optimistic-concurrency conflicts the way a GuaranteedUpdate loop does: re-GET
+// the object, re-apply the mutation on top of the latest resource version, and retry with
+// exponential backoff and jitter. origState is assumed current on the first attempt, so the
+// common case costs no extra GET.
+func updateExternalSecret(ctx context.Context, logger eventLogger, dynamicClient dynamic.Interface, es *unstructured.Unstructured, namespace string) error {
 	name := es.GetName()
+	current := es
+	delay := updateRetryBaseDelay
+
+	var lastErr error
+	for attempt := 1; attempt <= maxUpdateRetries; attempt++ {
+		if current == nil {
+			var latest *unstructured.Unstructured
+			err := retryableAPICall(ctx, logger, "Get ExternalSecret "+namespace+"/"+name, func() error {
+				fetched, err := dynamicClient.Resource(ExternalSecretGVR).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+				if err != nil {
+					return err
+				}
+				latest = fetched
+				return nil
+			})
+			if err != nil {
+				return fmt.Errorf("failed to refetch ExternalSecret %s: %w", name, err)
+			}
+			current = latest
+		}
 
-	// Function to update annotations and perform the update
-	updateFunc := func(es *unstructured.Unstructured) error {
-		annotations := es.GetAnnotations()
+		candidate := current.DeepCopy()
+		annotations := candidate.GetAnnotations()
 		if annotations == nil {
 			annotations = make(map[string]string)
 		}
-
 		annotations["updated-by"] = "externalsecret-updater"
 		annotations["updated-at"] = time.Now().Format(time.RFC3339)
-		es.SetAnnotations(annotations)
-
-		ctx.Logger.Infof("Updating ExternalSecret %s in namespace %s", name, namespace)
-		_, err := dynamicClient.Resource(ExternalSecretGVR).Namespace(namespace).Update(ctx, es, metav1.UpdateOptions{})
-		if err != nil {
-			ctx.Logger.Errorf("Failed to update ExternalSecret %s: %v", name, err)
+		// Bumping this annotation on every attempt guarantees the spec changes even when the
+		// rest of the object is untouched, so ESO always observes a diff without a second update.
+		annotations["force-sync-token"] = nextForceSyncToken(annotations["force-sync-token"])
+		candidate.SetAnnotations(annotations)
+
+		logger.Infof("Updating ExternalSecret %s in namespace %s (attempt %d/%d)", name, namespace, attempt, maxUpdateRetries)
+		err := retryableAPICall(ctx, logger, "Update ExternalSecret "+namespace+"/"+name, func() error {
+			_, err := dynamicClient.Resource(ExternalSecretGVR).Namespace(namespace).Update(ctx, candidate, metav1.UpdateOptions{})
 			return err
+		})
+		if err == nil {
+			logger.Infof("Successfully updated ExternalSecret %s", name)
+			return nil
 		}
-		ctx.Logger.Infof("Successfully updated ExternalSecret %s", name)
-		return nil
-	}
 
-	// Perform the first update
-	if err := updateFunc(es); err != nil {
-		return err
-	}
-
-	if enableCacheBuster {
-		ctx.Logger.Infof("Cache buster enabled. Waiting for %v before second update", cacheBusterWaitInterval)
-		time.Sleep(cacheBusterWaitInterval)
-
-		// Fetch the latest version of the ExternalSecret
-		latestES, err := dynamicClient.Resource(ExternalSecretGVR).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
-		if err != nil {
-			ctx.Logger.Errorf("Failed to fetch latest ExternalSecret %s: %v", name, err)
+		if !apierrors.IsConflict(err) {
+			logger.Errorf("Failed to update ExternalSecret %s: %v", name, err)
 			return err
 		}
 
-		ctx.Logger.Infof("Performing second update on ExternalSecret %s to bust cache", name)
-		if err := updateFunc(latestES); err != nil {
-			return err
-		}
-		ctx.Logger.Infof("Successfully performed second update on ExternalSecret %s", name)
-	} else {
-		ctx.Logger.Info("Cache buster is disabled")
+		lastErr = err
+		logger.Warnf("Conflict updating ExternalSecret %s, retrying against latest resource version: %v", name, err)
+		current = nil // force a re-GET before the next attempt
+
+		time.Sleep(delay + time.Duration(rand.Int63n(int64(delay)+1)))
+		delay *= 2
 	}
 
-	return nil
+	return fmt.Errorf("giving up updating ExternalSecret %s after %d attempts: %w", name, maxUpdateRetries, lastErr)
 }
 
-// Helper function to get duration from environment variable with a default value
-func getEnvDuration(ctx *gofr.Context, key string, defaultValue time.Duration) time.Duration {
-	value := os.Getenv(key)
-	if value == "" {
-		return defaultValue
-	}
-	duration, err := time.ParseDuration(value)
+// nextForceSyncToken increments the monotonic counter stored in the force-sync-token
+// annotation, starting from 0 if it is missing or not a valid integer.
+func nextForceSyncToken(current string) string {
+	n, err := strconv.ParseInt(current, 10, 64)
 	if err != nil {
-		ctx.Logger.Errorf("Invalid duration for %s, using default: %v", key, err)
-		return defaultValue
+		n = 0
 	}
-	return duration
+	return strconv.FormatInt(n+1, 10)
 }
 
 // logRequestDetails logs the entire request details
 func logRequestDetails(ctx *gofr.Context, events []Event) {
 	ctx.Logger.Debugf("Received events: %v", events)
 }
-
-// getNamespace retrieves the namespace from the in-cluster configuration
-// It reads the namespace from the file that Kubernetes mounts.
-func getNamespace() (string, error) {
-	data, err := os.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/namespace")
-	if err != nil {
-		return "", err
-	}
-	return string(data), nil
-}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// sourceMode selects how events are delivered to the trigger, via SOURCE_MODE.
+const (
+	sourceModeHTTP = "http"
+	sourceModeMQTT = "mqtt"
+)
+
+// mqttEventSource subscribes to an MQTT topic and feeds every message it receives through the
+// same Event decode path and patchExternalSecret pipeline used by WebhookHandler, so the
+// trigger works in environments where changes are published to a broker rather than delivered
+// via an HTTPS webhook.
+type mqttEventSource struct {
+	client mqtt.Client
+	topic  string
+}
+
+// newMQTTEventSourceFromEnv builds an mqttEventSource from MQTT_* environment variables.
+func newMQTTEventSourceFromEnv() (*mqttEventSource, error) {
+	brokerURL := os.Getenv("MQTT_BROKER_URL")
+	if brokerURL == "" {
+		return nil, fmt.Errorf("MQTT_BROKER_URL must be set when SOURCE_MODE=%s", sourceModeMQTT)
+	}
+	topic := os.Getenv("MQTT_TOPIC")
+	if topic == "" {
+		return nil, fmt.Errorf("MQTT_TOPIC must be set when SOURCE_MODE=%s", sourceModeMQTT)
+	}
+
+	opts := mqtt.NewClientOptions().AddBroker(brokerURL)
+	opts.SetClientID(getEnvOrDefault("MQTT_CLIENT_ID", "akeyless-eso-webhook-trigger"))
+
+	if username := os.Getenv("MQTT_USERNAME"); username != "" {
+		opts.SetUsername(username)
+		opts.SetPassword(os.Getenv("MQTT_PASSWORD"))
+	}
+
+	tlsConfig, err := mqttTLSConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		opts.SetTLSConfig(tlsConfig)
+	}
+
+	return &mqttEventSource{client: mqtt.NewClient(opts), topic: topic}, nil
+}
+
+// mqttTLSConfigFromEnv builds a *tls.Config from MQTT_TLS_CA_FILE and
+// MQTT_TLS_INSECURE_SKIP_VERIFY, or returns nil if neither is set.
+func mqttTLSConfigFromEnv() (*tls.Config, error) {
+	caFile := os.Getenv("MQTT_TLS_CA_FILE")
+	insecure := os.Getenv("MQTT_TLS_INSECURE_SKIP_VERIFY") == "true"
+
+	if caFile == "" && !insecure {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: insecure} //nolint:gosec // explicit opt-in via MQTT_TLS_INSECURE_SKIP_VERIFY
+
+	if caFile != "" {
+		caCert, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read MQTT_TLS_CA_FILE: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("no valid certificates found in MQTT_TLS_CA_FILE")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return tlsConfig, nil
+}
+
+// Start connects to the broker and subscribes to the configured topic, invoking process with
+// the item name decoded from every message received. It returns once the subscription is
+// established; messages are handled asynchronously on paho's own goroutines.
+func (s *mqttEventSource) Start(process func(itemName string)) error {
+	if token := s.client.Connect(); token.Wait() && token.Error() != nil {
+		return fmt.Errorf("failed to connect to MQTT broker: %w", token.Error())
+	}
+
+	handler := func(_ mqtt.Client, msg mqtt.Message) {
+		itemName, err := decodeMQTTMessage(msg.Payload())
+		if err != nil {
+			log.Printf("Failed to decode MQTT message on topic %s: %v", msg.Topic(), err)
+			return
+		}
+		if itemName != "" {
+			process(itemName)
+		}
+	}
+
+	if token := s.client.Subscribe(s.topic, 1, handler); token.Wait() && token.Error() != nil {
+		return fmt.Errorf("failed to subscribe to MQTT topic %s: %w", s.topic, token.Error())
+	}
+
+	return nil
+}
+
+// decodeMQTTMessage decodes a message payload using the same Event shape the HTTP webhook
+// accepts: either a single Event object or an array of Events, in which case the first is used.
+func decodeMQTTMessage(payload []byte) (string, error) {
+	var events []Event
+	if err := json.Unmarshal(payload, &events); err == nil && len(events) > 0 {
+		return events[0].ItemName, nil
+	}
+
+	var event Event
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return "", fmt.Errorf("failed to decode MQTT message: %w", err)
+	}
+	return event.ItemName, nil
+}
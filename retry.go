@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// Backoff parameters for retrying transient Kubernetes API errors, overridable via env vars so
+// operators can tune retry behavior per cluster without a rebuild.
+var (
+	apiRetryInitialInterval = getEnvDuration("API_RETRY_INITIAL_INTERVAL", 200*time.Millisecond)
+	apiRetryMaxInterval     = getEnvDuration("API_RETRY_MAX_INTERVAL", 5*time.Second)
+	apiRetryMaxElapsedTime  = getEnvDuration("API_RETRY_MAX_ELAPSED_TIME", 30*time.Second)
+	apiRetryMultiplier      = getEnvFloat("API_RETRY_MULTIPLIER", 2.0)
+)
+
+// retryableAPICall runs fn with a bounded exponential backoff, retrying errors that look like
+// transient apiserver hiccups (network errors, server timeouts, rate limiting, internal errors)
+// but returning immediately on permanent ones (NotFound, Forbidden, ...) so those don't burn the
+// retry budget. opName is used only for the log line on each retry.
+func retryableAPICall(ctx context.Context, logger eventLogger, opName string, fn func() error) error {
+	b := backoff.NewExponentialBackOff()
+	b.InitialInterval = apiRetryInitialInterval
+	b.MaxInterval = apiRetryMaxInterval
+	b.MaxElapsedTime = apiRetryMaxElapsedTime
+	b.Multiplier = apiRetryMultiplier
+
+	attempt := 0
+	return backoff.Retry(func() error {
+		attempt++
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		if !isRetryableAPIError(err) {
+			return backoff.Permanent(err)
+		}
+		logger.Warnf("Transient %s error on %s (attempt %d): %v", classifyAPIError(err), opName, attempt, err)
+		return err
+	}, backoff.WithContext(b, ctx))
+}
+
+// isRetryableAPIError reports whether err looks like a transient apiserver hiccup worth
+// retrying, as opposed to a permanent error like NotFound or Forbidden that retrying won't fix.
+func isRetryableAPIError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if apierrors.IsNotFound(err) || apierrors.IsForbidden(err) || apierrors.IsInvalid(err) || apierrors.IsBadRequest(err) {
+		return false
+	}
+	if apierrors.IsServerTimeout(err) || apierrors.IsTooManyRequests(err) ||
+		apierrors.IsInternalError(err) || apierrors.IsTimeout(err) || apierrors.IsUnexpectedServerError(err) {
+		return true
+	}
+
+	// The checks above only fire for a structured *StatusError the apiserver itself returned.
+	// A dropped connection, DNS failure, or dial timeout never reaches the apiserver at all, so
+	// it won't be a *StatusError -- and those plain transport errors are exactly the "network
+	// errors" this retry loop exists to ride out, so treat anything else as retryable too.
+	var statusErr *apierrors.StatusError
+	return !errors.As(err, &statusErr)
+}
+
+// classifyAPIError returns a short label for the error class, for log messages.
+func classifyAPIError(err error) string {
+	switch {
+	case apierrors.IsTooManyRequests(err):
+		return "TooManyRequests"
+	case apierrors.IsServerTimeout(err):
+		return "ServerTimeout"
+	case apierrors.IsTimeout(err):
+		return "Timeout"
+	case apierrors.IsInternalError(err):
+		return "InternalError"
+	default:
+		return "NetworkError"
+	}
+}
+
+// getEnvDuration reads a time.Duration from an env var, falling back to defaultValue if unset
+// or invalid.
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return defaultValue
+	}
+	return duration
+}
+
+// getEnvFloat reads a float64 from an env var, falling back to defaultValue if unset or invalid.
+func getEnvFloat(key string, defaultValue float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return defaultValue
+	}
+	return parsed
+}